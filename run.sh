@@ -6,6 +6,14 @@ YELLOW='\033[1;33m'
 LIGHT_BLUE='\033[1;34m'
 NC='\033[0m'
 
+# Exit codes, one per failure class, so scripts can react without parsing output
+EXIT_RUNTIME_NOT_FOUND=2
+EXIT_SERVICE_UNKNOWN=3
+EXIT_IMAGE_PULL_FAILED=4
+EXIT_COMPOSE_FAILED=5
+EXIT_PORTS_UNREACHABLE=6
+EXIT_HEALTH_TIMEOUT=7
+
 SCRIPT_DIR=$( cd -- "$( dirname -- "${BASH_SOURCE[0]}" )" &> /dev/null && pwd )
 
 connection_commands="
@@ -35,17 +43,40 @@ usage() {
   echo
   echo "    <services>                Name of services to run"
   echo "    -c, connect [service]     Connect to service"
+  echo "    ci up [services...]       Start services, wait until healthy and report per-service results"
+  echo "                              Add --timeout <duration> to limit the wait and --junit <file> for a JUnit report"
+  echo "    compose -- [args...]      Run docker compose with the insta-infra compose file"
+  echo "    cp [source] [destination] Copy files between a service and local path (use <service>:<path>)"
   echo "    -d, down [services...]    Shutdown services (if empty, shutdown all services)"
+  echo "                              Add --wipe-data to also remove their persisted data"
   echo "    -h, --help, help          Show help"
-  echo "    -l, list                  List supported services"
+  echo "    -l, list [--type <type>]  List supported services, optionally only of a service type"
+  echo "    -n, network               Show network details of running services"
   echo "    -r, remove [services...]  Remove persisted data (if empty, remove all services persisted data)"
+  echo "    -t, test [service]        Test service ports are reachable from the host"
   echo
   echo "Examples:"
   echo "    $(basename "$0") -l"
+  echo "    $(basename "$0") -l --type olap     List Real-time OLAP services"
   echo "    $(basename "$0") postgres           Spin up Postgres"
   echo "    $(basename "$0") -c postgres        Connect to Postgres"
-  echo "    $(basename "$0") -d                 Bring Postgres down"
+  echo "    $(basename "$0") ci up postgres --timeout 5m --junit report.xml  Start Postgres for CI"
+  echo "    $(basename "$0") compose -- ps      Show status of services via docker compose"
+  echo "    $(basename "$0") cp a.sql postgres:/tmp  Copy a.sql into the Postgres container"
+  echo "    $(basename "$0") -d                 Bring all services down"
+  echo "    $(basename "$0") -d postgres --wipe-data  Bring Postgres down and remove its persisted data"
+  echo "    $(basename "$0") -n                 Show IP addresses and aliases of running services"
   echo "    $(basename "$0") -r postgres        Remove Postgres persisted data"
+  echo "    $(basename "$0") -t postgres        Test connection to Postgres"
+  echo
+  echo "Exit codes:"
+  echo "    1    Invalid usage"
+  echo "    $EXIT_RUNTIME_NOT_FOUND    docker or docker compose not found"
+  echo "    $EXIT_SERVICE_UNKNOWN    Unknown service"
+  echo "    $EXIT_IMAGE_PULL_FAILED    Failed to pull images"
+  echo "    $EXIT_COMPOSE_FAILED    Failed to start up services"
+  echo "    $EXIT_PORTS_UNREACHABLE    Service not running or ports not reachable (test)"
+  echo "    $EXIT_HEALTH_TIMEOUT    Services did not become healthy (INSTA_START_STRATEGY=wait, ci up)"
   exit 0
 }
 
@@ -56,66 +87,369 @@ connect_to_service() {
     exit 1
   fi
 
-  echo -e "${GREEN}Connecting to $1...${NC}"
+  echo -e "${GREEN}Connecting to $1...${NC}" >&2
   base_command=$(echo "$connection_commands" | grep "^$1")
   IFS=$'\t' read -r container_name connection_command \
     < <(sed -nr "s/(.*)='(.*)'/\1\t\2/p" <<< "$base_command")
 
   if [ -z "$connection_command" ]
   then
-    echo -e "${RED}Error: Failed to find connection command for $1${NC}"
+    echo -e "${RED}Error: No connection command defined for $1${NC}"
+    exit 1
+  fi
+
+  # Only allocate a TTY when attached to a terminal, so input can be piped in (e.g. cat dump.sql | insta -c postgres)
+  if [ -t 0 ]; then
+    docker exec -it "$container_name" bash -c "$connection_command"
+  else
+    docker exec -i "$container_name" bash -c "$connection_command"
+  fi
+}
+
+# Prefer the docker compose plugin (v2), falling back to the standalone docker-compose binary (v1)
+detect_compose_command() {
+  if [ ${#compose_command[@]} -gt 0 ]; then
+    return
+  fi
+  if docker compose version &>/dev/null; then
+    compose_command=(docker compose)
+  elif command -v docker-compose &>/dev/null; then
+    compose_command=(docker-compose)
+  else
+    echo -e "${RED}Error: docker compose or docker-compose could not be found${NC}"
+    exit $EXIT_RUNTIME_NOT_FOUND
+  fi
+}
+
+docker_compose() {
+  detect_compose_command
+  "${compose_command[@]}" -f "$SCRIPT_DIR/docker-compose.yaml" "$@"
+}
+
+# Describe services from docker-compose.yaml as lines of: depends <service> <dependency>, healthcheck <service>,
+# ports <service> and persist <service> <data directory>. Relies on the layout enforced by the yml lint rules
+load_compose_model() {
+  if [ -n "$compose_model" ]; then
+    return
+  fi
+  compose_model=$(awk '
+    /^"/ { service = ""; next }
+    /^  "[^"]+":$/ { service = substr($1, 2, length($1) - 3); section = ""; next }
+    service == "" { next }
+    /^    "[^"]+":/ {
+      match($0, /"[^"]+"/)
+      section = substr($0, RSTART + 1, RLENGTH - 2)
+      if (section == "healthcheck" || section == "ports") print section, service
+      next
+    }
+    section == "depends_on" && /^      (- )?"[^"]+"/ {
+      match($0, /"[^"]+"/)
+      print "depends", service, substr($0, RSTART + 1, RLENGTH - 2)
+    }
+    section == "volumes" && /"\.\/data\/[^\/]+\/persist[:\/]/ {
+      split($0, parts, "/")
+      print "persist", service, parts[3]
+    }
+  ' "$SCRIPT_DIR/docker-compose.yaml" | sort -u)
+}
+
+# Sets service_closure to the services given along with everything they depend on
+resolve_service_closure() {
+  load_compose_model
+  service_closure=()
+  pending=("$@")
+  while [ ${#pending[@]} -gt 0 ]; do
+    service="${pending[0]}"
+    pending=("${pending[@]:1}")
+    if [[ " ${service_closure[*]} " == *" $service "* ]]; then
+      continue
+    fi
+    service_closure+=("$service")
+    pending+=($(awk -v s="$service" '$1 == "depends" && $2 == s {print $3}' <<< "$compose_model"))
+  done
+}
+
+run_compose() {
+  if [ "$1" = "--" ]; then
+    shift
+  fi
+  docker_compose "$@"
+}
+
+copy_files() {
+  if [ -z "$1" ] || [ -z "$2" ]; then
+    echo -e "${RED}Error: Source and destination must be passed as arguments${NC}"
+    exit 1
+  fi
+  if [ -n "$3" ]; then
+    echo -e "${RED}Error: Unknown argument $3${NC}"
+    exit 1
+  fi
+  if [[ "$1" != *:* ]] && [[ "$2" != *:* ]]; then
+    echo -e "${RED}Error: Source or destination must be a service path (e.g. postgres:/tmp/data.csv)${NC}"
     exit 1
   fi
 
-  docker exec -it "$container_name" bash -c "$connection_command"
+  service="${1%%:*}"
+  if [[ "$1" != *:* ]]; then
+    service="${2%%:*}"
+  fi
+  if ! docker inspect --type container "$service" &>/dev/null; then
+    echo -e "${RED}Error: No container found for service $service${NC}"
+    exit $EXIT_SERVICE_UNKNOWN
+  fi
+
+  docker cp "$1" "$2"
+}
+
+# Refuse to remove persisted data that a running container still has mounted (use * to check all data directories)
+check_persist_unmounted() {
+  running_ids=$(docker ps -q)
+  if [ -z "$running_ids" ]; then
+    return
+  fi
+  while read -r container_name mount_sources; do
+    for source in $mount_sources; do
+      for dir in "$@"; do
+        if [[ "$source" == "$SCRIPT_DIR/data/"$dir"/persist" || "$source" == "$SCRIPT_DIR/data/"$dir"/persist/"* ]]; then
+          echo -e "${RED}Error: Not removing persisted data, $source is still mounted by ${container_name#/}${NC}"
+          exit 1
+        fi
+      done
+    done
+  done < <(docker inspect --format '{{.Name}} {{range .Mounts}}{{.Source}} {{end}}' $running_ids)
 }
 
 shutdown_service() {
-  if [ -z "$1" ]; then
+  wipe_data="false"
+  services=()
+  for arg in "$@"; do
+    if [ "$arg" = "--wipe-data" ]; then
+      wipe_data="true"
+    else
+      services+=("$arg")
+    fi
+  done
+
+  # Data is persisted by the server service (e.g. postgres-server mounts data/postgres/persist), so find the directories
+  # for the services given and stop every service that mounts them before wiping
+  persist_dirs=()
+  if [ "$wipe_data" = "true" ] && [ ${#services[@]} -gt 0 ]; then
+    load_compose_model
+    for service in "${services[@]}"; do
+      persist_dirs+=($(awk -v s="$service" '$1 == "persist" && ($2 == s || $3 == s) {print $3}' <<< "$compose_model"))
+    done
+    persist_dirs=($(printf '%s\n' "${persist_dirs[@]}" | sort -u))
+    if [ ${#persist_dirs[@]} -eq 0 ]; then
+      echo -e "${YELLOW}No persisted data for services: ${services[*]}${NC}"
+      wipe_data="false"
+    fi
+    for dir in "${persist_dirs[@]}"; do
+      for service in $(awk -v d="$dir" '$1 == "persist" && $3 == d {print $2}' <<< "$compose_model"); do
+        if [[ " ${services[*]} " != *" $service "* ]]; then
+          services+=("$service")
+        fi
+      done
+    done
+  fi
+
+  if [ ${#services[@]} -eq 0 ]; then
     echo "Shutting down all services..."
-    docker-compose -f "$SCRIPT_DIR/docker-compose.yaml" down
+    docker_compose down
   else
-    echo "Shutting down services: $*..."
-    docker-compose -f "$SCRIPT_DIR/docker-compose.yaml" down "$@"
+    echo "Shutting down services: ${services[*]}..."
+    docker_compose down "${services[@]}"
   fi
+  rc=$?
+
+  if [ $rc = 0 ] && [ "$wipe_data" = "true" ]; then
+    if [ ${#persist_dirs[@]} -eq 0 ]; then
+      check_persist_unmounted "*"
+    else
+      check_persist_unmounted "${persist_dirs[@]}"
+    fi
+    remove_persisted_data "${persist_dirs[@]}"
+  fi
+  return $rc
 }
 
 list_supported_services() {
-  supported_services=$(awk '/## Services/{y=1;next}y' "$SCRIPT_DIR/README.md" | grep '✅' | awk -F'|' '{print $3}' | sort | xargs)
+  service_type=""
+  case "$1" in
+    "")
+      ;;
+    "--type")
+      if [ -z "$2" ]; then
+        echo -e "${RED}Error: No service type passed as argument${NC}"
+        exit 1
+      fi
+      if [ -n "$3" ]; then
+        echo -e "${RED}Error: Unknown argument $3${NC}"
+        exit 1
+      fi
+      service_type="$2"
+      ;;
+    *)
+      echo -e "${RED}Error: Unknown argument $1, expected --type <type>${NC}"
+      exit 1
+      ;;
+  esac
+
+  supported_services=$(awk '/## Services/{y=1;next}y' "$SCRIPT_DIR/README.md" | grep '✅' \
+    | awk -F'|' -v type="$service_type" 'type == "" || index(tolower($2), tolower(type)) {print $3}' | sort | xargs)
+  if [ -z "$supported_services" ]; then
+    echo -e "${RED}Error: No supported services with type $service_type${NC}"
+    exit 1
+  fi
   echo -e "Supported services: ${GREEN}$supported_services${NC}"
 }
 
 check_docker_installed() {
-  echo -e "${GREEN}Checking for docker and docker-compose...${NC}"
+  echo -e "${GREEN}Checking for docker and docker compose...${NC}"
   if ! command -v docker &>/dev/null; then
     echo -e "${RED}Error: docker could not be found${NC}"
-    exit 1
+    exit $EXIT_RUNTIME_NOT_FOUND
   fi
-  if ! command -v docker-compose &>/dev/null; then
-    echo -e "${RED}Error: docker-compose could not be found${NC}"
-    exit 1
+  detect_compose_command
+}
+
+check_services_exist() {
+  known_services=$(docker_compose config --services)
+  for service in "$@"; do
+    if ! grep -qx "$service" <<< "$known_services"; then
+      echo -e "${RED}Error: Unknown service $service${NC}"
+      exit $EXIT_SERVICE_UNKNOWN
+    fi
+  done
+}
+
+# Bind mounts from the Windows filesystem (/mnt/<drive>) are slow and don't propagate file change events under WSL
+check_wsl_paths() {
+  if grep -qi "microsoft" /proc/version 2>/dev/null && [[ "$SCRIPT_DIR" == /mnt/* ]]; then
+    echo -e "${YELLOW}Warning: Running under WSL from the Windows filesystem ($SCRIPT_DIR)${NC}"
+    echo -e "${YELLOW}Service data is bind mounted from here, which is slow. Clone insta-infra into your WSL home directory instead${NC}"
   fi
 }
 
+check_start_strategy() {
+  start_strategy="${INSTA_START_STRATEGY:-detached}"
+  case "$start_strategy" in
+    "detached"|"logs")
+      ;;
+    "wait")
+      compose_up_help=$(docker_compose up --help)
+      if ! grep -q -- "--wait " <<< "$compose_up_help"; then
+        echo -e "${YELLOW}Warning: docker compose does not support --wait, starting detached instead${NC}"
+        start_strategy="detached"
+      elif [ -n "$INSTA_WAIT_TIMEOUT" ] && ! grep -q -- "--wait-timeout" <<< "$compose_up_help"; then
+        echo -e "${YELLOW}Warning: docker compose does not support --wait-timeout, ignoring INSTA_WAIT_TIMEOUT${NC}"
+        INSTA_WAIT_TIMEOUT=""
+      fi
+      ;;
+    *)
+      echo -e "${RED}Error: Unknown start strategy $start_strategy, must be one of: detached, wait, logs${NC}"
+      exit 1
+      ;;
+  esac
+}
+
+# Requested services are often one-shot loaders (e.g. postgres loads data into postgres-server, then exits), which
+# compose --wait treats as failed once they exit. Only wait on long-running services, those with a healthcheck or
+# published ports, and check that any one-shot services that already finished exited successfully
+wait_for_services() {
+  resolve_service_closure "$@"
+  long_running=()
+  one_shot=()
+  for service in "${service_closure[@]}"; do
+    if grep -qE "^(healthcheck|ports) $service$" <<< "$compose_model"; then
+      long_running+=("$service")
+    else
+      one_shot+=("$service")
+    fi
+  done
+
+  echo -e "${GREEN}Waiting for services to be healthy...${NC}"
+  if [ ${#long_running[@]} -gt 0 ]; then
+    wait_args=(--wait)
+    if [ -n "$INSTA_WAIT_TIMEOUT" ]; then
+      wait_args+=(--wait-timeout "$INSTA_WAIT_TIMEOUT")
+    fi
+    docker_compose up -d "${wait_args[@]}" "${long_running[@]}"
+    if [ $? != 0 ]; then
+      echo -e "${RED}Error: Services did not become healthy${NC}"
+      exit $EXIT_HEALTH_TIMEOUT
+    fi
+  fi
+
+  for service in "${one_shot[@]}"; do
+    container_id=$(docker_compose ps -a -q "$service")
+    if [ -z "$container_id" ]; then
+      continue
+    fi
+    read -r status exit_code < <(docker inspect --format '{{.State.Status}} {{.State.ExitCode}}' "$container_id")
+    if [ "$status" = "exited" ] && [ "$exit_code" != "0" ]; then
+      echo -e "${RED}Error: Service $service exited with code $exit_code${NC}"
+      exit $EXIT_HEALTH_TIMEOUT
+    fi
+  done
+}
+
 startup_services() {
   all_services=("$@")
-  echo -e "${GREEN}Starting up services...${NC}"
-  docker-compose -f "$SCRIPT_DIR/docker-compose.yaml" up -d "$@"
+  # Pull missing images up front so pull failures are reported separately. Older compose versions without
+  # pull --policy leave pulling to up, where a failed pull is reported as a startup failure
+  if docker_compose pull --help | grep -q -- "--policy"; then
+    echo -e "${GREEN}Pulling missing images...${NC}"
+    docker_compose pull --policy missing --include-deps "$@"
+    if [ $? != 0 ]; then
+      echo -e "${RED}Error: Failed to pull images${NC}"
+      exit $EXIT_IMAGE_PULL_FAILED
+    fi
+  fi
+  echo -e "${GREEN}Starting up services ($start_strategy)...${NC}"
+  docker_compose up -d "$@"
   if [ $? != 0 ]; then
     echo -e "${RED}Error: Failed to start up services${NC}"
-    exit 1
+    exit $EXIT_COMPOSE_FAILED
+  fi
+
+  if [ "$start_strategy" = "wait" ]; then
+    wait_for_services "$@"
+  else
+    sleep 2
   fi
-  sleep 2
+}
+
+host_address() {
+  case "${INSTA_BIND_ADDRESS:-127.0.0.1}" in
+    "127.0.0.1"|"0.0.0.0"|"::"|"[::]")
+      echo "localhost"
+      ;;
+    *)
+      echo "$INSTA_BIND_ADDRESS"
+      ;;
+  esac
+}
+
+# host.docker.internal only resolves under Docker Desktop, so on plain Linux docker the network gateway is used instead
+is_docker_desktop() {
+  [ "$(uname -s)" != "Linux" ] || docker info --format '{{.OperatingSystem}}' | grep -q "Docker Desktop"
 }
 
 log_how_to_connect() {
   echo -e "${GREEN}How to connect:${NC}"
+  host=$(host_address)
+  is_docker_desktop && docker_desktop="true" || docker_desktop="false"
   connect_result=("${YELLOW}Service,${YELLOW}Container To Container,Host To Container,Container To Host")
   for service in "${all_services[@]}"; do
+    docker_host="host.docker.internal"
+    if [ "$docker_desktop" = "false" ]; then
+      docker_host=$(docker inspect --format '{{range .NetworkSettings.Networks}}{{.Gateway}} {{end}}' "$service" | awk '{print $1}')
+    fi
     ports=$(docker inspect "$service" | grep HostPort | sed -nr 's/.*\: "([0-9]+)"/\1/p' | sort -u)
     for port in $ports; do
       container_port=$(docker inspect "$service" | grep -B 3 "HostPort\": \"${port}\"" | sed -nr 's/.*\"([0-9]+)\/tcp\".*/\1/p' | head -1)
-      current_service="${RED}$service,${LIGHT_BLUE}$service:$container_port,localhost:$port,host.docker.internal:$port"
+      current_service="${RED}$service,${LIGHT_BLUE}$service:$container_port,$host:$port,$docker_host:$port"
       connect_result+=("$current_service")
     done
   done
@@ -123,6 +457,188 @@ log_how_to_connect() {
   for value in "${connect_result[@]}"; do
       echo -e "$value"
   done | column -t -s ','
+
+  # Ports published on loopback can't be reached from containers through the gateway address
+  if [ "$docker_desktop" = "false" ]; then
+    case "${INSTA_BIND_ADDRESS:-127.0.0.1}" in
+      127.*|"localhost"|"::1"|"[::1]")
+        echo -e "${YELLOW}Warning: Container To Host addresses only work if ports are published on all interfaces (e.g. INSTA_BIND_ADDRESS=0.0.0.0)${NC}"
+        ;;
+    esac
+  fi
+}
+
+show_network() {
+  detect_compose_command
+  container_ids=$(docker_compose ps -q)
+  if [ -z "$container_ids" ]; then
+    echo -e "${YELLOW}No services are running${NC}"
+    return
+  fi
+
+  echo -e "${GREEN}Network:${NC}"
+  network_format='{{if .State.Running}}{{range $network, $config := .NetworkSettings.Networks}}{{$.Name}},{{$network}},{{$config.IPAddress}},{{join $config.Aliases " "}}{{println}}{{end}}{{end}}'
+  network_result=("${YELLOW}Container,${YELLOW}Network,IP Address,Aliases")
+  while IFS= read -r line; do
+    network_result+=("${RED}${line%%,*},${LIGHT_BLUE}${line#*,}")
+  done < <(docker inspect --format "$network_format" $container_ids | sed -n 's/^\///p')
+
+  for value in "${network_result[@]}"; do
+      echo -e "$value"
+  done | column -t -s ','
+  echo -e "${GREEN}Containers on the same network reach each other by container name or alias (e.g. postgres:5432)${NC}"
+}
+
+test_connection() {
+  if [ -z "$1" ]
+  then
+    echo -e "${RED}Error: No service name passed as argument${NC}"
+    exit 1
+  fi
+
+  if [ "$(docker inspect --type container --format '{{.State.Running}}' "$1" 2>/dev/null)" != "true" ]; then
+    echo -e "${RED}Error: Service $1 is not running${NC}"
+    exit $EXIT_PORTS_UNREACHABLE
+  fi
+
+  echo -e "${GREEN}Testing connection to $1...${NC}"
+  host=$(host_address | tr -d '[]')
+  failed="false"
+  ports_format='{{range $port, $bindings := .NetworkSettings.Ports}}{{range $bindings}}{{.HostPort}} {{end}}{{end}}'
+  ports=$(docker inspect --type container --format "$ports_format" "$1" | tr ' ' '\n' | grep -v '^$' | sort -u)
+  if [ -z "$ports" ]; then
+    echo -e "${YELLOW}No ports published for $1${NC}"
+    return
+  fi
+  for port in $ports; do
+    if (exec 3<>"/dev/tcp/$host/$port") &>/dev/null; then
+      echo -e "${GREEN}$host:$port reachable${NC}"
+    else
+      echo -e "${RED}$host:$port not reachable${NC}"
+      failed="true"
+    fi
+  done
+
+  if [ "$failed" = "true" ]; then
+    exit $EXIT_PORTS_UNREACHABLE
+  fi
+}
+
+# Set the wait timeout in seconds from a duration (e.g. 90, 90s, 5m, 1h)
+set_wait_timeout() {
+  if [[ ! "$1" =~ ^([0-9]+)([smh]?)$ ]]; then
+    echo -e "${RED}Error: Invalid timeout $1, must be a number of seconds or end with s, m or h (e.g. 5m)${NC}"
+    exit 1
+  fi
+  case "${BASH_REMATCH[2]}" in
+    "m") INSTA_WAIT_TIMEOUT=$((BASH_REMATCH[1] * 60)) ;;
+    "h") INSTA_WAIT_TIMEOUT=$((BASH_REMATCH[1] * 3600)) ;;
+    *) INSTA_WAIT_TIMEOUT="${BASH_REMATCH[1]}" ;;
+  esac
+}
+
+ci_up() {
+  ci_services=()
+  ci_junit=""
+  while [ $# -gt 0 ]; do
+    case "$1" in
+      "--timeout")
+        if [ -z "$2" ]; then
+          echo -e "${RED}Error: No timeout passed as argument${NC}"
+          exit 1
+        fi
+        set_wait_timeout "$2"
+        shift 2
+        ;;
+      "--junit")
+        if [ -z "$2" ]; then
+          echo -e "${RED}Error: No JUnit report file passed as argument${NC}"
+          exit 1
+        fi
+        ci_junit="$2"
+        shift 2
+        ;;
+      -*)
+        echo -e "${RED}Error: Unknown argument $1${NC}"
+        exit 1
+        ;;
+      *)
+        ci_services+=("$1")
+        shift
+        ;;
+    esac
+  done
+  if [ ${#ci_services[@]} -eq 0 ]; then
+    echo -e "${RED}Error: No service names passed as argument${NC}"
+    exit 1
+  fi
+
+  INSTA_START_STRATEGY="wait"
+  check_docker_installed
+  check_wsl_paths
+  check_services_exist "${ci_services[@]}"
+  check_start_strategy
+  ci_started_at=$SECONDS
+  # Report on exit, so failed pulls and startups also produce per-service results
+  trap report_ci_results EXIT
+  startup_services "${ci_services[@]}"
+}
+
+# A service passed if it is running and healthy (when it has a healthcheck), or it is a data loader that exited with 0
+report_ci_results() {
+  rc=$?
+  trap - EXIT
+  resolve_service_closure "${ci_services[@]}"
+  failures=0
+  testcases=""
+  echo -e "${GREEN}Startup results:${NC}"
+  for service in "${service_closure[@]}"; do
+    failure=""
+    container_id=$(docker_compose ps -a -q "$service" 2>/dev/null)
+    if [ -z "$container_id" ]; then
+      failure="container not created"
+    else
+      read -r status exit_code health < <(docker inspect --type container \
+        --format '{{.State.Status}} {{.State.ExitCode}} {{if .State.Health}}{{.State.Health.Status}}{{end}}' "$container_id")
+      if [ "$status" = "exited" ] && [ "$exit_code" = "0" ]; then
+        :
+      elif [ "$status" = "exited" ]; then
+        failure="container exited with code $exit_code"
+      elif [ "$status" != "running" ]; then
+        failure="container $status"
+      elif [ -n "$health" ] && [ "$health" != "healthy" ]; then
+        failure="container $health"
+      fi
+    fi
+
+    if [ -z "$failure" ]; then
+      echo -e "${GREEN}  $service passed${NC}"
+      testcases+="    <testcase classname=\"insta-infra\" name=\"$service\"/>"$'\n'
+    else
+      echo -e "${RED}  $service failed: $failure${NC}"
+      failures=$((failures + 1))
+      testcases+="    <testcase classname=\"insta-infra\" name=\"$service\">"$'\n'
+      testcases+="      <failure message=\"$failure\"/>"$'\n'
+      testcases+="    </testcase>"$'\n'
+    fi
+  done
+
+  if [ -n "$ci_junit" ]; then
+    {
+      echo '<?xml version="1.0" encoding="UTF-8"?>'
+      echo "<testsuites>"
+      echo "  <testsuite name=\"insta-infra\" tests=\"${#service_closure[@]}\" failures=\"$failures\" time=\"$((SECONDS - ci_started_at))\">"
+      printf "%s" "$testcases"
+      echo "  </testsuite>"
+      echo "</testsuites>"
+    } > "$ci_junit"
+    echo -e "${GREEN}Wrote JUnit report to $ci_junit${NC}"
+  fi
+
+  if [ "$rc" = "0" ] && [ $failures -gt 0 ]; then
+    exit $EXIT_HEALTH_TIMEOUT
+  fi
+  exit "$rc"
 }
 
 remove_persisted_data() {
@@ -154,22 +670,50 @@ case $1 in
   "-c"|"connect")
     connect_to_service "$2"
     ;;
+  "ci")
+    if [ "$2" != "up" ]; then
+      echo -e "${RED}Error: Unknown ci command $2, must be: up${NC}"
+      exit 1
+    fi
+    ci_up "${@:3}"
+    ;;
+  "compose")
+    run_compose "${@:2}"
+    ;;
+  "cp")
+    copy_files "${@:2}"
+    ;;
   "-d"|"down")
     shutdown_service "${@:2}"
     ;;
   "-l"|"list")
-    list_supported_services
+    list_supported_services "${@:2}"
+    ;;
+  "-n"|"network")
+    show_network
     ;;
   "-r"|"remove")
     remove_persisted_data "${@:2}"
     ;;
+  "-t"|"test")
+    test_connection "$2"
+    ;;
   *)
     if [ $# -eq 0 ]; then
       usage
     else
       check_docker_installed
+      check_wsl_paths
+      check_services_exist "$@"
+      check_start_strategy
       startup_services "$@"
       log_how_to_connect
+      # Requested services are often one-shot init containers (e.g. kafka, postgres), so also follow the servers
+      # they depend on
+      if [ "$start_strategy" = "logs" ]; then
+        resolve_service_closure "$@"
+        docker_compose logs -f "${service_closure[@]}"
+      fi
     fi
     ;;
 esac